@@ -0,0 +1,67 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import "fmt"
+
+// coinType is a SLIP-0044 registered coin type index
+// (https://github.com/satoshilabs/slips/blob/master/slip-0044.md), used as
+// the hardened third component of a BIP-44 derivation path.
+type coinType uint32
+
+// Well-known SLIP-0044 coin types supported by BaseDerivationPathFor. The
+// "test" and "testnet" entries use SLIP-44's reserved testnet coin type 1',
+// shared by all testnets.
+const (
+	coinTypeBitcoin  coinType = 0
+	coinTypeTestnet  coinType = 1
+	coinTypeEthereum coinType = 60
+	coinTypeEvrice   coinType = 1020
+)
+
+// coinTypes maps the canonical names accepted by BaseDerivationPathFor to
+// their SLIP-0044 coin type.
+var coinTypes = map[string]coinType{
+	"btc":      coinTypeBitcoin,
+	"bitcoin":  coinTypeBitcoin,
+	"eth":      coinTypeEthereum,
+	"ethereum": coinTypeEthereum,
+	"evr":      coinTypeEvrice,
+	"evrice":   coinTypeEvrice,
+	"test":     coinTypeTestnet,
+	"testnet":  coinTypeTestnet,
+}
+
+// BaseDerivationPathFor returns the canonical BIP-44 base derivation path
+// m/44'/coin_type'/0'/0/0 for the named coin, suitable for passing to
+// DefaultIterator, whose result can in turn be handed to Wallet.SelfDerive
+// or DiscoverAccounts. coin is matched case-sensitively
+// against the keys registered in coinTypes (e.g. "ethereum", "bitcoin",
+// "evrice", "testnet").
+func BaseDerivationPathFor(coin string) (DerivationPath, error) {
+	ct, ok := coinTypes[coin]
+	if !ok {
+		return nil, fmt.Errorf("unknown coin type %q", coin)
+	}
+	return DerivationPath{
+		0x80000000 + 44,
+		0x80000000 + uint32(ct),
+		0x80000000 + 0,
+		0,
+		0,
+	}, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import "fmt"
+
+// DefaultGapLimit is the number of consecutive unused addresses that must be
+// observed before DiscoverAccounts stops scanning a derivation branch. It
+// mirrors the gap limit convention used by BIP-44 compatible wallets.
+const DefaultGapLimit = 20
+
+// DiscoveredAccount pairs a derivation path with the account it derived to,
+// as returned by a completed DiscoverAccounts scan.
+type DiscoveredAccount struct {
+	Path    DerivationPath
+	Account Account
+}
+
+// DiscoveryProgress is reported to the optional progress callback passed to
+// DiscoverAccounts after every probed address, so that a UI can render an
+// ongoing account recovery/import scan.
+type DiscoveryProgress struct {
+	Path  DerivationPath // Path that was just probed
+	Used  bool           // Whether the address at Path had any on-chain activity
+	Empty int            // Current length of the trailing run of unused addresses
+}
+
+// DiscoverAccounts performs a one-shot BIP-44 style gap-limit account
+// discovery scan against wallet, walking it to produce successive
+// derivation paths (e.g. DefaultIterator, LedgerLiveIterator, or a
+// Composite of several historical schemes). It probes each derived address
+// against chain and stops once gapLimit consecutive addresses are found
+// with both a zero balance and a zero nonce, returning every account
+// observed to have been used along the way.
+//
+// it may also be bounded, such as a DerivationPathTemplate.Iterator(); once
+// it is exhausted and Next returns nil, the scan stops immediately and
+// returns the accounts found so far, rather than probing a nil path.
+//
+// When it is a Composite, each sub-iterator gets its own gap-limit counter:
+// a run of empty addresses on one historical scheme does not count against
+// the others, and the scan only stops once every sub-iterator has hit
+// gapLimit consecutive empties. Without this, a Composite of N sources would
+// round-robin the shared counter across them, making the effective gap
+// tolerance per source gapLimit/N instead of gapLimit.
+//
+// A gapLimit of 0 selects DefaultGapLimit. If progress is non-nil, it is
+// invoked after every probed address.
+//
+// Unlike Wallet.SelfDerive, which opportunistically pins newly discovered
+// accounts in the background as a wallet is used, DiscoverAccounts is meant
+// for one-shot recovery and import flows: it blocks until the scan completes
+// and returns the full result set rather than mutating wallet state.
+func DiscoverAccounts(wallet Wallet, it Iterator, chain ChainStateReader, gapLimit int, progress func(DiscoveryProgress)) ([]DiscoveredAccount, error) {
+	if gapLimit <= 0 {
+		gapLimit = DefaultGapLimit
+	}
+	indexer, multiplexed := it.(sourceIndexer)
+	numSources := 1
+	if multiplexed {
+		numSources = indexer.sources()
+	}
+
+	var (
+		used   []DiscoveredAccount
+		empty  = make([]int, numSources)
+		source int
+	)
+	for !allReachedGapLimit(empty, gapLimit) {
+		path := it.Next()
+		if path == nil {
+			return used, nil
+		}
+		if multiplexed {
+			source = indexer.lastSource()
+		}
+
+		account, err := wallet.Derive(path, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s: %v", path, err)
+		}
+		balance, err := chain.BalanceAt(account.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch balance for %s: %v", path, err)
+		}
+		nonce, err := chain.NonceAt(account.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch nonce for %s: %v", path, err)
+		}
+
+		isUsed := balance.Sign() > 0 || nonce > 0
+		if isUsed {
+			empty[source] = 0
+			used = append(used, DiscoveredAccount{Path: append(DerivationPath{}, path...), Account: account})
+		} else {
+			empty[source]++
+		}
+		if progress != nil {
+			progress(DiscoveryProgress{Path: append(DerivationPath{}, path...), Used: isUsed, Empty: empty[source]})
+		}
+	}
+	return used, nil
+}
+
+// allReachedGapLimit reports whether every per-source gap counter in empty
+// has reached gapLimit, i.e. whether a scan is done probing.
+func allReachedGapLimit(empty []int, gapLimit int) bool {
+	for _, e := range empty {
+		if e < gapLimit {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,223 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/evrice/go-evrice/common"
+	"github.com/evrice/go-evrice/core/types"
+)
+
+// stubWallet derives deterministic, distinguishable addresses for any path so
+// tests can assert on which paths were probed without touching real key material.
+type stubWallet struct{}
+
+func (stubWallet) URL() URL                                                { return URL{} }
+func (stubWallet) Status() (string, error)                                 { return "", nil }
+func (stubWallet) Open(passphrase string) error                            { return nil }
+func (stubWallet) Close() error                                            { return nil }
+func (stubWallet) Accounts() []Account                                     { return nil }
+func (stubWallet) Contains(account Account) bool                           { return false }
+func (stubWallet) SelfDerive(iterators []Iterator, chain ChainStateReader) {}
+func (stubWallet) SignData(account Account, mimeType string, data []byte) ([]byte, error) {
+	return nil, nil
+}
+func (stubWallet) SignDataWithPassphrase(account Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return nil, nil
+}
+func (stubWallet) SignText(account Account, text []byte) ([]byte, error) { return nil, nil }
+func (stubWallet) SignTextWithPassphrase(account Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, nil
+}
+func (stubWallet) SignTx(account Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return tx, nil
+}
+func (stubWallet) SignTxWithPassphrase(account Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return tx, nil
+}
+
+func (stubWallet) Derive(path DerivationPath, pin bool) (Account, error) {
+	var addr common.Address
+	copy(addr[:], path.String())
+	return Account{Address: addr, URL: URL{Scheme: "stub", Path: path.String()}}, nil
+}
+
+// stubChain reports activity for a fixed set of addresses, identified by the
+// derivation path string baked into them by stubWallet.Derive.
+type stubChain struct {
+	used map[string]bool
+}
+
+func (c stubChain) BalanceAt(account common.Address) (*big.Int, error) {
+	if c.used[account.Hex()] {
+		return big.NewInt(1), nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (c stubChain) NonceAt(account common.Address) (uint64, error) {
+	return 0, nil
+}
+
+func addressFor(path DerivationPath) common.Address {
+	var addr common.Address
+	copy(addr[:], path.String())
+	return addr
+}
+
+func TestDiscoverAccountsStopsAtGapLimit(t *testing.T) {
+	w := stubWallet{}
+
+	usedPaths := []DerivationPath{
+		append(DerivationPath{}, DefaultBaseDerivationPath...),
+	}
+	usedPaths[0][len(usedPaths[0])-1] = 0 // first address is used
+
+	used := map[string]bool{addressFor(usedPaths[0]).Hex(): true}
+	chain := stubChain{used: used}
+
+	var probed int
+	accounts, err := DiscoverAccounts(w, DefaultIterator(DefaultBaseDerivationPath), chain, 3, func(p DiscoveryProgress) {
+		probed++
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accounts) != 1 {
+		t.Fatalf("have %d used accounts, want 1", len(accounts))
+	}
+	// index 0 used, then 1,2,3 empty => gap limit of 3 reached after 4 probes
+	if probed != 4 {
+		t.Errorf("have %d probes, want 4", probed)
+	}
+}
+
+func TestDiscoverAccountsCompositePerSourceGapLimit(t *testing.T) {
+	w := stubWallet{}
+
+	// The default iterator's own index 2 is used, well within a gap limit
+	// of 3 consecutive empties on that branch alone. But interleaved with a
+	// second, always-empty iterator via Composite, 3 *total* empties land
+	// before that probe (default/0, legacy/0, default/1). A shared gap
+	// counter (the bug under test) would stop the scan right there and
+	// never find this account; per-source counters must keep each branch's
+	// own gap limit independent of how much traffic the other branch gets.
+	usedPath := append(DerivationPath{}, DefaultBaseDerivationPath...)
+	usedPath[len(usedPath)-1] = 2
+	used := map[string]bool{addressFor(usedPath).Hex(): true}
+	chain := stubChain{used: used}
+
+	it := Composite(DefaultIterator(DefaultBaseDerivationPath), NewLegacyIterator(1020))
+	accounts, err := DiscoverAccounts(w, it, chain, 3, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accounts) != 1 {
+		t.Fatalf("have %d used accounts, want 1", len(accounts))
+	}
+	if have := accounts[0].Path.String(); have != usedPath.String() {
+		t.Errorf("have used account %s, want %s", have, usedPath)
+	}
+}
+
+func TestDiscoverAccountsStopsAtExhaustedTemplateIterator(t *testing.T) {
+	w := stubWallet{}
+
+	tmpl, err := ParseDerivationPathTemplate("m/44'/1020'/0'/0/0-2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Every probed address is reported as used, including the nil-path
+	// fallback "m" address a buggy DiscoverAccounts would keep re-deriving
+	// past exhaustion. If the scan doesn't stop as soon as the bounded
+	// iterator runs dry, it hangs forever resetting the gap counter on
+	// every iteration.
+	chain := stubChain{used: map[string]bool{
+		addressFor(DerivationPath{0x80000000 + 44, 0x80000000 + 1020, 0x80000000 + 0, 0, 0}).Hex(): true,
+		addressFor(DerivationPath{0x80000000 + 44, 0x80000000 + 1020, 0x80000000 + 0, 0, 1}).Hex(): true,
+		addressFor(DerivationPath{0x80000000 + 44, 0x80000000 + 1020, 0x80000000 + 0, 0, 2}).Hex(): true,
+		addressFor(nil).Hex(): true,
+	}}
+
+	done := make(chan struct{})
+	var accounts []DiscoveredAccount
+	go func() {
+		accounts, err = DiscoverAccounts(w, tmpl.Iterator(), chain, 5, nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("DiscoverAccounts did not return; likely looping on the exhausted iterator")
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accounts) != 3 {
+		t.Fatalf("have %d used accounts, want 3", len(accounts))
+	}
+}
+
+func TestDiscoverAccountsStopsAtExhaustedTemplateIteratorInComposite(t *testing.T) {
+	w := stubWallet{}
+	chain := stubChain{used: map[string]bool{}}
+
+	tmpl, err := ParseDerivationPathTemplate("m/44'/1020'/0'/0/0-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The template exhausts after 2 paths on its turns; DefaultIterator
+	// never does. The scan must stop as soon as the composed iterator
+	// yields nil, rather than deriving a nil path forever.
+	it := Composite(tmpl.Iterator(), DefaultIterator(DefaultBaseDerivationPath))
+
+	done := make(chan struct{})
+	var accounts []DiscoveredAccount
+	go func() {
+		accounts, err = DiscoverAccounts(w, it, chain, 5, nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("DiscoverAccounts did not return; likely looping on the exhausted iterator")
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accounts) != 0 {
+		t.Fatalf("have %d used accounts, want 0", len(accounts))
+	}
+}
+
+func TestDiscoverAccountsDefaultGapLimit(t *testing.T) {
+	w := stubWallet{}
+	chain := stubChain{used: map[string]bool{}}
+
+	accounts, err := DiscoverAccounts(w, LedgerLiveIterator(DefaultBaseDerivationPath), chain, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accounts) != 0 {
+		t.Errorf("have %d used accounts, want 0", len(accounts))
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// componentTemplate is the parsed form of a single path component, which may
+// either be a plain BIP-32 index (the common case) or a descriptor-style
+// range expression, as defined by the output descriptor spec
+// (https://github.com/bitcoin/bips/blob/master/bip-0380.mediawiki) and its
+// wildcard/multipath extensions:
+//
+//	0, 0'          a single fixed index, hardened or not
+//	*, *'          a wildcard, meaning "every index from 0 up to (but not
+//	               including) defaultWildcardSpan"
+//	0-99, 0'-99'   an inclusive range of indices
+//	{0,1}'         an explicit enumerated set of indices
+//	<0;1>'         a "multipath" set, semantically identical to {0,1}'
+type componentTemplate struct {
+	values   []uint32 // Concrete values this component can take, in order
+	wildcard bool     // Whether values should be extended up to defaultWildcardSpan
+	hardened bool     // Whether every value in this component is hardened
+}
+
+// DerivationPathTemplate represents a descriptor-style derivation path that
+// may contain wildcard, range or enumerated-set components, e.g.
+// "m/44'/1020'/0'/0/*" or "m/44'/1020'/{0,1}'/0/0-99". It generalizes the
+// concrete DerivationPath to the set of paths such an expression matches.
+type DerivationPathTemplate []componentTemplate
+
+// defaultWildcardSpan bounds how many indices a "*" component expands to,
+// both in Expand and in Iterator; the span is fixed and cannot be changed
+// per template.
+const defaultWildcardSpan = 1000
+
+// ParseDerivationPathTemplate converts a descriptor-style derivation path
+// expression into a DerivationPathTemplate. It accepts absolute ("m/")
+// paths of decimal, optionally hardened, components, plus wildcard ("*"),
+// range ("a-b") and enumerated-set ("{a,b,...}" or the equivalent
+// "<a;b;...>" multipath syntax) components. Unlike ParseDerivationPath, it
+// rejects relative paths and hexadecimal components. Whitespace is ignored,
+// as in ParseDerivationPath.
+func ParseDerivationPathTemplate(path string) (DerivationPathTemplate, error) {
+	components := strings.Split(path, "/")
+	if len(components) == 0 {
+		return nil, fmt.Errorf("empty derivation path template")
+	}
+	if strings.TrimSpace(components[0]) != "m" {
+		return nil, fmt.Errorf("descriptor-style templates must be absolute and start with 'm/'")
+	}
+	components = components[1:]
+	if len(components) == 0 {
+		return nil, fmt.Errorf("empty derivation path template")
+	}
+
+	template := make(DerivationPathTemplate, 0, len(components))
+	for _, component := range components {
+		component = strings.TrimSpace(component)
+		parsed, err := parseComponentTemplate(component)
+		if err != nil {
+			return nil, fmt.Errorf("invalid component %q: %v", component, err)
+		}
+		template = append(template, parsed)
+	}
+	return template, nil
+}
+
+// parseComponentTemplate parses a single descriptor-style path component.
+func parseComponentTemplate(component string) (componentTemplate, error) {
+	hardened := false
+	if strings.HasSuffix(component, "'") {
+		hardened = true
+		component = strings.TrimSpace(strings.TrimSuffix(component, "'"))
+	}
+
+	switch {
+	case component == "*":
+		return componentTemplate{wildcard: true, hardened: hardened}, nil
+
+	case strings.HasPrefix(component, "{") && strings.HasSuffix(component, "}"):
+		return parseSetTemplate(component[1:len(component)-1], ",", hardened)
+
+	case strings.HasPrefix(component, "<") && strings.HasSuffix(component, ">"):
+		return parseSetTemplate(component[1:len(component)-1], ";", hardened)
+
+	case strings.Contains(component, "-"):
+		bounds := strings.SplitN(component, "-", 2)
+		if len(bounds) != 2 {
+			return componentTemplate{}, fmt.Errorf("malformed range")
+		}
+		start := strings.TrimSpace(bounds[0])
+		if hardened {
+			start = strings.TrimSpace(strings.TrimSuffix(start, "'"))
+		}
+		lo, err := strconv.ParseUint(start, 10, 32)
+		if err != nil {
+			return componentTemplate{}, fmt.Errorf("malformed range start: %v", err)
+		}
+		hi, err := strconv.ParseUint(strings.TrimSpace(bounds[1]), 10, 32)
+		if err != nil {
+			return componentTemplate{}, fmt.Errorf("malformed range end: %v", err)
+		}
+		if hi < lo {
+			return componentTemplate{}, fmt.Errorf("range end %d before start %d", hi, lo)
+		}
+		values := make([]uint32, 0, hi-lo+1)
+		for v := lo; v <= hi; v++ {
+			values = append(values, uint32(v))
+		}
+		return componentTemplate{values: values, hardened: hardened}, nil
+
+	default:
+		v, err := strconv.ParseUint(component, 10, 32)
+		if err != nil {
+			return componentTemplate{}, fmt.Errorf("invalid index: %v", err)
+		}
+		return componentTemplate{values: []uint32{uint32(v)}, hardened: hardened}, nil
+	}
+}
+
+// parseSetTemplate parses the comma/semicolon separated body of a "{...}" or
+// "<...>" enumerated-set component.
+func parseSetTemplate(body, sep string, hardened bool) (componentTemplate, error) {
+	parts := strings.Split(body, sep)
+	values := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		v, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err != nil {
+			return componentTemplate{}, fmt.Errorf("invalid set member %q: %v", part, err)
+		}
+		values = append(values, uint32(v))
+	}
+	if len(values) == 0 {
+		return componentTemplate{}, fmt.Errorf("empty set")
+	}
+	return componentTemplate{values: values, hardened: hardened}, nil
+}
+
+// resolved returns the raw DerivationPath component for value v, applying
+// the hardened bit if the template component is hardened.
+func (c componentTemplate) resolved(v uint32) uint32 {
+	if c.hardened {
+		return 0x80000000 + v
+	}
+	return v
+}
+
+// Expand materializes every concrete DerivationPath matched by the template,
+// as the Cartesian product of its components, in lexicographic order of
+// each component's values as written (ranges and wildcards ascend; set
+// members keep their listed order). Wildcard components expand to
+// [0, defaultWildcardSpan), as they do in Iterator. Callers that want to
+// avoid materializing the full product up front should use Iterator instead.
+func (t DerivationPathTemplate) Expand() []DerivationPath {
+	var paths []DerivationPath
+	it := t.Iterator()
+	for {
+		path := it.Next()
+		if path == nil {
+			break
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// templateIterator lazily walks the Cartesian product of a
+// DerivationPathTemplate's components. It implements the Iterator interface
+// (iterator.go), so a template can be passed anywhere a DefaultIterator or
+// LedgerLiveIterator is accepted, including Composite, Wallet.SelfDerive and
+// DiscoverAccounts.
+type templateIterator struct {
+	template DerivationPathTemplate
+	counters []int
+	done     bool
+	peeked   bool
+	peekPath DerivationPath // cached Peek result; nil once the template is exhausted
+}
+
+// Iterator returns a streaming iterator over every concrete DerivationPath
+// matched by the template, walking the Cartesian product of its components
+// lazily so that templates with large or wildcard spans don't need to be
+// materialized up front. Once exhausted, Next and Peek return nil.
+func (t DerivationPathTemplate) Iterator() Iterator {
+	return &templateIterator{
+		template: t,
+		counters: make([]int, len(t)),
+	}
+}
+
+// span returns how many values component c can take, using
+// defaultWildcardSpan for wildcard components.
+func (c componentTemplate) span() int {
+	if c.wildcard {
+		return defaultWildcardSpan
+	}
+	return len(c.values)
+}
+
+// valueAt returns the i'th concrete index for component c (i itself for
+// wildcards).
+func (c componentTemplate) valueAt(i int) uint32 {
+	if c.wildcard {
+		return uint32(i)
+	}
+	return c.values[i]
+}
+
+// current materializes the DerivationPath for the odometer's present
+// position, or nil once every combination has been produced.
+func (it *templateIterator) current() DerivationPath {
+	if it.done || len(it.template) == 0 {
+		return nil
+	}
+	path := make(DerivationPath, len(it.template))
+	for i, c := range it.template {
+		path[i] = c.resolved(c.valueAt(it.counters[i]))
+	}
+	return path
+}
+
+// advance steps the odometer forward, carrying into more significant
+// (earlier) components the same way DefaultIterator increments the last
+// one, and marks the iterator done once the most significant component
+// overflows.
+func (it *templateIterator) advance() {
+	for i := len(it.template) - 1; i >= 0; i-- {
+		it.counters[i]++
+		if it.counters[i] < it.template[i].span() {
+			return
+		}
+		it.counters[i] = 0
+		if i == 0 {
+			it.done = true
+		}
+	}
+}
+
+// Peek returns the derivation path that the following call to Next would
+// return, without advancing the iterator. It returns nil once the template
+// is exhausted.
+func (it *templateIterator) Peek() DerivationPath {
+	if !it.peeked {
+		it.peekPath = it.current()
+		it.peeked = true
+	}
+	return it.peekPath
+}
+
+// Next returns the next concrete DerivationPath matched by the template, or
+// nil once every combination has been produced.
+func (it *templateIterator) Next() DerivationPath {
+	path := it.Peek()
+	it.peeked = false
+	if path != nil {
+		it.advance()
+	}
+	return path
+}
+
+// Reset rewinds the iterator back to the start of the Cartesian product, so
+// that the next call to Next reproduces the first path it ever returned.
+func (it *templateIterator) Reset() {
+	for i := range it.counters {
+		it.counters[i] = 0
+	}
+	it.done = false
+	it.peeked = false
+}
+
+// MatchTemplate reports whether path is one of the concrete paths matched by
+// template, without expanding it. It is intended for whitelisting derivation
+// paths in signing flows, e.g. "only sign for paths under
+// m/44'/1020'/{0,1}'/0/*".
+func MatchTemplate(path DerivationPath, template DerivationPathTemplate) bool {
+	if len(path) != len(template) {
+		return false
+	}
+	for i, c := range template {
+		if c.wildcard {
+			v := path[i]
+			if c.hardened {
+				if v < 0x80000000 {
+					return false
+				}
+				v -= 0x80000000
+			} else if v >= 0x80000000 {
+				return false
+			}
+			if v >= defaultWildcardSpan {
+				return false
+			}
+			continue
+		}
+		var match bool
+		for _, v := range c.values {
+			if c.resolved(v) == path[i] {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
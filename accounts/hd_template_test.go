@@ -0,0 +1,133 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import "testing"
+
+func TestParseDerivationPathTemplateRange(t *testing.T) {
+	tmpl, err := ParseDerivationPathTemplate("m/44'/1020'/0'/0/0-2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	paths := tmpl.Expand()
+	want := []string{"m/44'/1020'/0'/0/0", "m/44'/1020'/0'/0/1", "m/44'/1020'/0'/0/2"}
+	if len(paths) != len(want) {
+		t.Fatalf("have %d paths, want %d", len(paths), len(want))
+	}
+	for i, p := range paths {
+		if have := p.String(); have != want[i] {
+			t.Errorf("path %d: have %s, want %s", i, have, want[i])
+		}
+	}
+}
+
+func TestParseDerivationPathTemplateSetAndMultipath(t *testing.T) {
+	for _, expr := range []string{"m/44'/1020'/{0,1}'/0/0", "m/44'/1020'/<0;1>'/0/0"} {
+		tmpl, err := ParseDerivationPathTemplate(expr)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", expr, err)
+		}
+		paths := tmpl.Expand()
+		want := []string{"m/44'/1020'/0'/0/0", "m/44'/1020'/1'/0/0"}
+		if len(paths) != len(want) {
+			t.Fatalf("%s: have %d paths, want %d", expr, len(paths), len(want))
+		}
+		for i, p := range paths {
+			if have := p.String(); have != want[i] {
+				t.Errorf("%s: path %d: have %s, want %s", expr, i, have, want[i])
+			}
+		}
+	}
+}
+
+func TestDerivationPathTemplateIteratorWildcard(t *testing.T) {
+	tmpl, err := ParseDerivationPathTemplate("m/44'/1020'/0'/0/*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	it := tmpl.Iterator()
+	for i := 0; i < 5; i++ {
+		path := it.Next()
+		want := DerivationPath{0x80000000 + 44, 0x80000000 + 1020, 0x80000000 + 0, 0, uint32(i)}
+		if path.String() != want.String() {
+			t.Errorf("step %d: have %v, want %v", i, path, want)
+		}
+	}
+}
+
+func TestDerivationPathTemplateIteratorSatisfiesIterator(t *testing.T) {
+	tmpl, err := ParseDerivationPathTemplate("m/44'/1020'/0'/0/0-2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A template's Iterator() must be usable anywhere the Iterator interface
+	// is, including composed with other derivation strategies.
+	legacy := NewLegacyIterator(1020)
+	it := Composite(tmpl.Iterator(), legacy)
+
+	first := it.Next()  // template, index 0
+	it.Next()           // legacy, index 0
+	second := it.Next() // template, index 1
+	if first.String() != "m/44'/1020'/0'/0/0" {
+		t.Errorf("have %v, want m/44'/1020'/0'/0/0", first)
+	}
+	if second.String() != "m/44'/1020'/0'/0/1" {
+		t.Errorf("have %v, want m/44'/1020'/0'/0/1", second)
+	}
+
+	// Peek/Reset must behave like any other Iterator implementation.
+	direct := tmpl.Iterator()
+	peeked := direct.Peek()
+	if have := direct.Next(); have.String() != peeked.String() {
+		t.Errorf("Next after Peek returned %v, want %v", have, peeked)
+	}
+	direct.Next()
+	direct.Reset()
+	if have := direct.Next(); have.String() != "m/44'/1020'/0'/0/0" {
+		t.Errorf("have %v after Reset, want m/44'/1020'/0'/0/0", have)
+	}
+
+	// Once exhausted, Next/Peek return nil rather than panicking or
+	// wrapping around, so callers (e.g. DiscoverAccounts) can detect the
+	// end of a bounded template.
+	exhausted := tmpl.Iterator()
+	for i := 0; i < 3; i++ {
+		exhausted.Next()
+	}
+	if have := exhausted.Next(); have != nil {
+		t.Errorf("have %v past exhaustion, want nil", have)
+	}
+	if have := exhausted.Peek(); have != nil {
+		t.Errorf("Peek past exhaustion returned %v, want nil", have)
+	}
+}
+
+func TestMatchTemplate(t *testing.T) {
+	tmpl, err := ParseDerivationPathTemplate("m/44'/1020'/{0,1}'/0/0-99")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	match, _ := ParseDerivationPath("m/44'/1020'/1'/0/42")
+	if !MatchTemplate(match, tmpl) {
+		t.Errorf("expected %v to match template", match)
+	}
+	noMatch, _ := ParseDerivationPath("m/44'/1020'/2'/0/42")
+	if MatchTemplate(noMatch, tmpl) {
+		t.Errorf("expected %v not to match template", noMatch)
+	}
+}
@@ -63,12 +63,12 @@ func TestHDPathParsing(t *testing.T) {
 		{"	m  /   44			'\n/\n   1020	\n\n\t'   /\n0 ' /\t\t	0", DerivationPath{0x80000000 + 44, 0x80000000 + 1020, 0x80000000 + 0, 0}},
 
 		// Invalid derivation paths
-		{"", nil},              // Empty relative derivation path
-		{"m", nil},             // Empty absolute derivation path
-		{"m/", nil},            // Missing last derivation component
+		{"", nil},                // Empty relative derivation path
+		{"m", nil},               // Empty absolute derivation path
+		{"m/", nil},              // Missing last derivation component
 		{"/44'/1020'/0'/0", nil}, // Absolute path without m prefix, might be user error
-		{"m/2147483648'", nil}, // Overflows 32 bit integer
-		{"m/-1'", nil},         // Cannot contain negative number
+		{"m/2147483648'", nil},   // Overflows 32 bit integer
+		{"m/-1'", nil},           // Cannot contain negative number
 	}
 	for i, tt := range tests {
 		if path, err := ParseDerivationPath(tt.input); !reflect.DeepEqual(path, tt.output) {
@@ -79,10 +79,10 @@ func TestHDPathParsing(t *testing.T) {
 	}
 }
 
-func testDerive(t *testing.T, next func() DerivationPath, expected []string) {
+func testDerive(t *testing.T, it Iterator, expected []string) {
 	t.Helper()
 	for i, want := range expected {
-		if have := next(); fmt.Sprintf("%v", have) != want {
+		if have := it.Next(); fmt.Sprintf("%v", have) != want {
 			t.Errorf("step %d, have %v, want %v", i, have, want)
 		}
 	}
@@ -0,0 +1,217 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+// Iterator walks a sequence of derivation paths according to some
+// account-discovery strategy (e.g. incrementing the address index,
+// incrementing the account, alternating change chains). Most sequences are
+// unbounded; a bounded one, such as DerivationPathTemplate.Iterator(),
+// returns nil from Next and Peek once exhausted. Implementations are not
+// required to be safe for concurrent use.
+type Iterator interface {
+	// Next returns the next derivation path in the sequence and advances
+	// the iterator.
+	Next() DerivationPath
+
+	// Peek returns the derivation path that the following call to Next
+	// would return, without advancing the iterator.
+	Peek() DerivationPath
+
+	// Reset rewinds the iterator back to its initial state, so that the
+	// next call to Next reproduces the first path it ever returned.
+	Reset()
+}
+
+// sourceIndexer is implemented by Iterators that multiplex several
+// independent derivation sources behind a single Iterator, such as
+// Composite. DiscoverAccounts type-asserts for it so a scan over a
+// multiplexed Iterator can track a separate gap-limit counter per source,
+// rather than one counter shared across every source's turns.
+type sourceIndexer interface {
+	// sources reports how many independent sources are multiplexed.
+	sources() int
+
+	// lastSource reports which source index served the most recent call to
+	// Next.
+	lastSource() int
+}
+
+// indexIterator is an Iterator that walks a derivation path by repeatedly
+// incrementing a single component, starting from base's own value at that
+// component. DefaultIterator and LedgerLiveIterator are both backed by it,
+// differing only in which component they bump.
+type indexIterator struct {
+	base     DerivationPath
+	bump     int
+	next     uint32
+	peeked   bool
+	peekPath DerivationPath
+}
+
+func newIndexIterator(base DerivationPath, bump int) Iterator {
+	return &indexIterator{base: base, bump: bump}
+}
+
+func (it *indexIterator) Peek() DerivationPath {
+	if !it.peeked {
+		path := make(DerivationPath, len(it.base))
+		copy(path, it.base)
+		path[it.bump] += it.next
+		it.peekPath = path
+		it.peeked = true
+	}
+	return it.peekPath
+}
+
+func (it *indexIterator) Next() DerivationPath {
+	path := it.Peek()
+	it.peeked = false
+	it.next++
+	return path
+}
+
+func (it *indexIterator) Reset() {
+	it.next = 0
+	it.peeked = false
+}
+
+// DefaultIterator creates a BIP-32 path iterator, which progresses by increasing the
+// last component: m/44'/1020'/0'/0/0, m/44'/1020'/0'/0/1, m/44'/1020'/0'/0/2, ... m/44'/1020'/0'/0/N.
+func DefaultIterator(base DerivationPath) Iterator {
+	return newIndexIterator(base, len(base)-1)
+}
+
+// LedgerLiveIterator creates a bip44 path iterator for Ledger Live.
+// Ledger Live increments the third component, rather than the fifth component
+// i.e. m/44'/1020'/0'/0/0, m/44'/1020'/1'/0/0, m/44'/1020'/2'/0/0, ... m/44'/1020'/N'/0/0.
+func LedgerLiveIterator(base DerivationPath) Iterator {
+	return newIndexIterator(base, 2)
+}
+
+// NewLegacyIterator creates an iterator matching the pre-EIP-84 "MEW" layout
+// used by legacy Ledger firmware and early wallet software, which never
+// introduced a change level: m/44'/coinType'/0'/0, m/44'/coinType'/0'/1, ...
+func NewLegacyIterator(coinType uint32) Iterator {
+	base := DerivationPath{0x80000000 + 44, 0x80000000 + coinType, 0x80000000 + 0, 0}
+	return newIndexIterator(base, len(base)-1)
+}
+
+// changeChainIterator is an Iterator that alternates between the receive
+// (.../0/i) and change (.../1/i) branches of a BIP-44 account for the same
+// index before moving on to the next index, i.e. .../0/0, .../1/0, .../0/1,
+// .../1/1, ...
+type changeChainIterator struct {
+	account  DerivationPath // m/44'/coin_type'/account'
+	index    uint32
+	change   uint32
+	peeked   bool
+	peekPath DerivationPath
+}
+
+// NewBIP44ChangeIterator creates an Iterator that walks both the receive and
+// change chains of the BIP-44 account identified by account (a 3-component
+// path of the form m/44'/coin_type'/account').
+func NewBIP44ChangeIterator(account DerivationPath) Iterator {
+	return &changeChainIterator{account: account}
+}
+
+func (it *changeChainIterator) path() DerivationPath {
+	path := make(DerivationPath, len(it.account)+2)
+	copy(path, it.account)
+	path[len(it.account)] = it.change
+	path[len(it.account)+1] = it.index
+	return path
+}
+
+func (it *changeChainIterator) Peek() DerivationPath {
+	if !it.peeked {
+		it.peekPath = it.path()
+		it.peeked = true
+	}
+	return it.peekPath
+}
+
+func (it *changeChainIterator) Next() DerivationPath {
+	path := it.Peek()
+	it.peeked = false
+	if it.change == 0 {
+		it.change = 1
+	} else {
+		it.change = 0
+		it.index++
+	}
+	return path
+}
+
+func (it *changeChainIterator) Reset() {
+	it.index = 0
+	it.change = 0
+	it.peeked = false
+}
+
+// compositeIterator round-robins Next calls across a set of iterators, so
+// that a single scan can walk several historical derivation schemes (e.g.
+// Ledger Live, MEW/legacy, BIP-44 change-chain) in one pass.
+type compositeIterator struct {
+	iters []Iterator
+	turn  int
+	last  int
+}
+
+// Composite combines iters into a single Iterator that round-robins between
+// them, one path per sub-iterator per turn. Composite panics if iters is
+// empty.
+//
+// Composite also implements the unexported sourceIndexer interface, so that
+// DiscoverAccounts can track a separate gap-limit counter per sub-iterator
+// instead of one counter shared across every sub-iterator's turns.
+func Composite(iters ...Iterator) Iterator {
+	if len(iters) == 0 {
+		panic("accounts: Composite requires at least one iterator")
+	}
+	return &compositeIterator{iters: iters}
+}
+
+func (it *compositeIterator) Next() DerivationPath {
+	it.last = it.turn
+	path := it.iters[it.turn].Next()
+	it.turn = (it.turn + 1) % len(it.iters)
+	return path
+}
+
+func (it *compositeIterator) Peek() DerivationPath {
+	return it.iters[it.turn].Peek()
+}
+
+func (it *compositeIterator) Reset() {
+	for _, sub := range it.iters {
+		sub.Reset()
+	}
+	it.turn = 0
+	it.last = 0
+}
+
+// sources reports how many independent sub-iterators it multiplexes.
+func (it *compositeIterator) sources() int {
+	return len(it.iters)
+}
+
+// lastSource reports which sub-iterator index served the most recent call
+// to Next.
+func (it *compositeIterator) lastSource() int {
+	return it.last
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import "testing"
+
+func TestIteratorPeekDoesNotAdvance(t *testing.T) {
+	it := DefaultIterator(DefaultBaseDerivationPath)
+	first := it.Peek()
+	if second := it.Peek(); second.String() != first.String() {
+		t.Fatalf("Peek is not idempotent: %v != %v", first, second)
+	}
+	if have := it.Next(); have.String() != first.String() {
+		t.Fatalf("Next after Peek returned %v, want %v", have, first)
+	}
+	if have := it.Peek(); have.String() == first.String() {
+		t.Fatalf("Peek after Next still returned the consumed path %v", have)
+	}
+}
+
+func TestIteratorReset(t *testing.T) {
+	it := LedgerLiveIterator(DefaultBaseDerivationPath)
+	first := it.Next()
+	it.Next()
+	it.Reset()
+	if have := it.Next(); have.String() != first.String() {
+		t.Errorf("have %v after Reset, want %v", have, first)
+	}
+}
+
+func TestBIP44ChangeIterator(t *testing.T) {
+	account := DerivationPath{0x80000000 + 44, 0x80000000 + 1020, 0x80000000 + 0}
+	testDerive(t, NewBIP44ChangeIterator(account), []string{
+		"m/44'/1020'/0'/0/0", "m/44'/1020'/0'/1/0",
+		"m/44'/1020'/0'/0/1", "m/44'/1020'/0'/1/1",
+		"m/44'/1020'/0'/0/2", "m/44'/1020'/0'/1/2",
+	})
+}
+
+func TestLegacyIterator(t *testing.T) {
+	testDerive(t, NewLegacyIterator(1020), []string{
+		"m/44'/1020'/0'/0", "m/44'/1020'/0'/1", "m/44'/1020'/0'/2",
+	})
+}
+
+func TestCompositeIteratorRoundRobins(t *testing.T) {
+	a := DefaultIterator(DefaultBaseDerivationPath)
+	b := NewLegacyIterator(1020)
+	testDerive(t, Composite(a, b), []string{
+		"m/44'/1020'/0'/0/0", "m/44'/1020'/0'/0",
+		"m/44'/1020'/0'/0/1", "m/44'/1020'/0'/1",
+	})
+}
+
+func TestCompositePanicsOnEmpty(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Composite() with no iterators to panic")
+		}
+	}()
+	Composite()
+}
@@ -0,0 +1,242 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/evrice/go-evrice/crypto"
+)
+
+// Curve identifies the elliptic curve (or, for ed25519, the twisted Edwards
+// curve) a master seed and its derived children are defined over, per
+// SLIP-0010 (https://github.com/satoshilabs/slips/blob/master/slip-0010.md).
+type Curve int
+
+const (
+	// CurveSecp256k1 is the curve used by Bitcoin, Ethereum and Evrice.
+	CurveSecp256k1 Curve = iota
+	// CurveEd25519 is the curve used by e.g. Stellar and Solana. SLIP-0010
+	// restricts ed25519 to hardened-only derivation, since the curve has no
+	// defined point addition compatible with non-hardened child keys.
+	CurveEd25519
+	// CurveNist256p1 is NIST P-256, a.k.a. secp256r1.
+	CurveNist256p1
+)
+
+// seedKey is the HMAC-SHA512 key used to derive the master key and chain
+// code from a BIP-39 seed, one per supported curve.
+var seedKey = map[Curve]string{
+	CurveSecp256k1: "Bitcoin seed",
+	CurveEd25519:   "ed25519 seed",
+	CurveNist256p1: "Nist256p1 seed",
+}
+
+// curveParams returns the elliptic.Curve backing curve, for curves that
+// support non-hardened derivation (i.e. all but ed25519).
+func curveParams(curve Curve) (elliptic.Curve, error) {
+	switch curve {
+	case CurveSecp256k1:
+		return crypto.S256(), nil
+	case CurveNist256p1:
+		return elliptic.P256(), nil
+	default:
+		return nil, fmt.Errorf("curve %s has no elliptic.Curve representation", curve)
+	}
+}
+
+// String implements the stringer interface.
+func (c Curve) String() string {
+	switch c {
+	case CurveSecp256k1:
+		return "secp256k1"
+	case CurveEd25519:
+		return "ed25519"
+	case CurveNist256p1:
+		return "nist256p1"
+	default:
+		return fmt.Sprintf("unknown curve %d", int(c))
+	}
+}
+
+// hardened reports whether a raw derivation path component carries the
+// hardened bit (component >= 2^31).
+func hardened(component uint32) bool {
+	return component >= 0x80000000
+}
+
+// DeriveKey derives the private key and chain code for path starting from
+// seed, according to SLIP-0010, using curve.
+//
+// For CurveEd25519, SLIP-0010 only defines hardened derivation: DeriveKey
+// returns an error if path contains any non-hardened component. For
+// CurveNist256p1, a derived scalar that is zero or >= the curve order is
+// discarded and re-derived as I = HMAC-SHA512(chainCode, 0x01 || IR || ser32(i))
+// until a valid scalar is found, as specified by SLIP-0010.
+func DeriveKey(seed []byte, path DerivationPath, curve Curve) (key, chainCode []byte, err error) {
+	key, chainCode, err = masterKey(seed, curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, component := range path {
+		if curve == CurveEd25519 && !hardened(component) {
+			return nil, nil, fmt.Errorf("curve %s only supports hardened derivation, component %d is not hardened", curve, component)
+		}
+		key, chainCode, err = deriveChild(key, chainCode, component, curve)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return key, chainCode, nil
+}
+
+// masterKey computes the SLIP-0010 master key and chain code for seed on the
+// given curve.
+func masterKey(seed []byte, curve Curve) (key, chainCode []byte, err error) {
+	hmacKey, ok := seedKey[curve]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported curve %d", int(curve))
+	}
+	mac := hmac.New(sha512.New, []byte(hmacKey))
+	mac.Write(seed)
+	I := mac.Sum(nil)
+
+	key, chainCode = I[:32], I[32:]
+	if curve == CurveNist256p1 {
+		key, chainCode, err = retryUntilValidNist256p1(key, chainCode)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return key, chainCode, nil
+}
+
+// deriveChild derives the SLIP-0010 child key and chain code for a single
+// derivation path component.
+func deriveChild(key, chainCode []byte, component uint32, curve Curve) (childKey, childChainCode []byte, err error) {
+	var data []byte
+	if hardened(component) {
+		data = append([]byte{0x00}, key...)
+	} else {
+		ec, err := curveParams(curve)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = compressPubkey(ec, key)
+	}
+	data = append(data, ser32(component)...)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	I := mac.Sum(nil)
+
+	if curve == CurveNist256p1 {
+		return retryNist256p1Child(key, chainCode, component, I)
+	}
+	childKey, childChainCode = I[:32], I[32:]
+	if curve == CurveSecp256k1 {
+		childKey = addScalarsMod(key, childKey, crypto.S256().Params().N)
+	}
+	return childKey, childChainCode, nil
+}
+
+// ser32 serializes a uint32 as a 4-byte big-endian index, as defined by
+// BIP-32 and reused by SLIP-0010.
+func ser32(i uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, i)
+	return b
+}
+
+// retryUntilValidNist256p1 re-derives the master key if the initial HMAC
+// output yields an invalid (zero or >= n) nist256p1 scalar, following the
+// SLIP-0010 retry rule I = HMAC-SHA512(chainCode, 0x01 || IR || ser32(i)).
+func retryUntilValidNist256p1(key, chainCode []byte) ([]byte, []byte, error) {
+	n := elliptic.P256().Params().N
+	for i := uint32(0); ; i++ {
+		k := new(big.Int).SetBytes(key)
+		if k.Sign() != 0 && k.Cmp(n) < 0 {
+			return key, chainCode, nil
+		}
+		if i > 1<<16 {
+			return nil, nil, errors.New("nist256p1: exhausted retry budget deriving a valid scalar")
+		}
+		data := append([]byte{0x01}, chainCode...)
+		data = append(data, ser32(i)...)
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		I := mac.Sum(nil)
+		key, chainCode = I[:32], I[32:]
+	}
+}
+
+// retryNist256p1Child applies the same SLIP-0010 retry rule as
+// retryUntilValidNist256p1, but for a single child derivation step.
+func retryNist256p1Child(parentKey, parentChainCode []byte, component uint32, I []byte) ([]byte, []byte, error) {
+	n := elliptic.P256().Params().N
+	key, chainCode := I[:32], I[32:]
+	for i := uint32(0); ; i++ {
+		il := new(big.Int).SetBytes(key)
+		if il.Cmp(n) < 0 {
+			scalar := addScalarsMod(parentKey, key, n)
+			if k := new(big.Int).SetBytes(scalar); k.Sign() != 0 {
+				return scalar, chainCode, nil
+			}
+		}
+		if i > 1<<16 {
+			return nil, nil, errors.New("nist256p1: exhausted retry budget deriving a valid child scalar")
+		}
+		data := append([]byte{0x01}, chainCode...)
+		data = append(data, ser32(component)...)
+		mac := hmac.New(sha512.New, parentChainCode)
+		mac.Write(data)
+		I = mac.Sum(nil)
+		key, chainCode = I[:32], I[32:]
+	}
+}
+
+// addScalarsMod returns (a + b) mod n, encoded back to a 32-byte big-endian
+// scalar.
+func addScalarsMod(a, b []byte, n *big.Int) []byte {
+	sum := new(big.Int).Add(new(big.Int).SetBytes(a), new(big.Int).SetBytes(b))
+	sum.Mod(sum, n)
+
+	out := make([]byte, 32)
+	sum.FillBytes(out)
+	return out
+}
+
+// compressPubkey derives the SEC1-compressed public key for a private scalar
+// on ec, used as the HMAC input for non-hardened derivation.
+func compressPubkey(ec elliptic.Curve, priv []byte) []byte {
+	x, y := ec.ScalarBaseMult(priv)
+
+	prefix := byte(0x02)
+	if y.Bit(0) == 1 {
+		prefix = 0x03
+	}
+	out := make([]byte, 33)
+	out[0] = prefix
+	x.FillBytes(out[1:])
+	return out
+}
@@ -0,0 +1,174 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestDeriveKeySLIP10Vectors checks DeriveKey against the official SLIP-0010
+// test vectors (https://github.com/satoshilabs/slips/blob/master/slip-0010.md),
+// which give the master and several child keys for all three supported
+// curves from the two SLIP-0010 test seeds. Unlike TestDeriveKeyDeterministic, this pins
+// DeriveKey to known-correct output, so a transposed HMAC key/data argument,
+// an off-by-one in the hardened/non-hardened prefix, or a sign bug in
+// addScalarsMod/retryNist256p1Child would be caught rather than silently
+// reproduced on every run.
+func TestDeriveKeySLIP10Vectors(t *testing.T) {
+	seed1, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	if err != nil {
+		t.Fatalf("failed to decode seed: %v", err)
+	}
+	seed2, err := hex.DecodeString("fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542")
+	if err != nil {
+		t.Fatalf("failed to decode seed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		seed      []byte
+		curve     Curve
+		path      DerivationPath
+		key       string
+		chainCode string
+	}{
+		// SLIP-0010 secp256k1 test vector 1 (identical to BIP-32, since
+		// SLIP-0010 secp256k1 derivation is BIP-32 derivation).
+		{"secp256k1/vector1/m", seed1, CurveSecp256k1, DerivationPath{},
+			"e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+			"873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508"},
+		{"secp256k1/vector1/m/0H", seed1, CurveSecp256k1, DerivationPath{0x80000000},
+			"edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+			"47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141"},
+		{"secp256k1/vector1/m/0H/1", seed1, CurveSecp256k1, DerivationPath{0x80000000, 1},
+			"3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+			"2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19"},
+		{"secp256k1/vector2/m", seed2, CurveSecp256k1, DerivationPath{},
+			"4b03d6fc340455b363f51020ad3ecca4f0850280cf436c70c727923f6db46c3e",
+			"60499f801b896d83179a4374aeb7822aaeaceaa0db1f85ee3e904c4defbd9689"},
+
+		// SLIP-0010 nist256p1 test vector 1, exercising retryNist256p1Child.
+		{"nist256p1/vector1/m", seed1, CurveNist256p1, DerivationPath{},
+			"612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2",
+			"beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea"},
+		{"nist256p1/vector1/m/0H", seed1, CurveNist256p1, DerivationPath{0x80000000},
+			"6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c",
+			"3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11"},
+		{"nist256p1/vector2/m", seed2, CurveNist256p1, DerivationPath{},
+			"eaa31c2e46ca2962227cf21d73a7ef0ce8b31c756897521eb6c7b39796633357",
+			"96cd4465a9644e31528eda3592aa35eb39a9527769ce1855beafc1b81055e75d"},
+
+		// SLIP-0010 ed25519 test vector 1, hardened-only.
+		{"ed25519/vector1/m", seed1, CurveEd25519, DerivationPath{},
+			"2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+			"90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb"},
+		{"ed25519/vector1/m/0H", seed1, CurveEd25519, DerivationPath{0x80000000},
+			"68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+			"8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69"},
+		{"ed25519/vector1/m/0H/1H", seed1, CurveEd25519, DerivationPath{0x80000000, 0x80000001},
+			"b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+			"a320425f77d1b5c2505a6b1b27382b37368ee640e3557c315416801243552f14"},
+		{"ed25519/vector2/m", seed2, CurveEd25519, DerivationPath{},
+			"171cb88b1b3c1db25add599712e36245d75bc65a1a5c9e18d76f9f2b1eab4012",
+			"ef70a74db9c3a5af931b5fe73ed8e1a53464133654fd55e7a66f8570b8e33c3b"},
+	}
+
+	for _, tt := range tests {
+		key, chainCode, err := DeriveKey(tt.seed, tt.path, tt.curve)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if have := hex.EncodeToString(key); have != tt.key {
+			t.Errorf("%s: key: have %s, want %s", tt.name, have, tt.key)
+		}
+		if have := hex.EncodeToString(chainCode); have != tt.chainCode {
+			t.Errorf("%s: chain code: have %s, want %s", tt.name, have, tt.chainCode)
+		}
+	}
+}
+
+func TestDeriveKeyEd25519RejectsNonHardened(t *testing.T) {
+	path, err := ParseDerivationPath("m/44'/1020'/0'/0")
+	if err != nil {
+		t.Fatalf("failed to parse path: %v", err)
+	}
+	if _, _, err := DeriveKey(make([]byte, 32), path, CurveEd25519); err == nil {
+		t.Fatal("expected an error deriving a non-hardened ed25519 path, got nil")
+	}
+}
+
+func TestDeriveKeyEd25519AllHardened(t *testing.T) {
+	path, err := ParseDerivationPath("m/44'/1020'/0'/0'")
+	if err != nil {
+		t.Fatalf("failed to parse path: %v", err)
+	}
+	key, chainCode, err := DeriveKey(make([]byte, 32), path, CurveEd25519)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(key) != 32 || len(chainCode) != 32 {
+		t.Fatalf("have key/chainCode lengths %d/%d, want 32/32", len(key), len(chainCode))
+	}
+}
+
+func TestDeriveKeyDeterministic(t *testing.T) {
+	path, err := ParseDerivationPath(DefaultBaseDerivationPath.String())
+	if err != nil {
+		t.Fatalf("failed to parse path: %v", err)
+	}
+	seed := bytes.Repeat([]byte{0x01}, 32)
+
+	key1, cc1, err := DeriveKey(seed, path, CurveSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key2, cc2, err := DeriveKey(seed, path, CurveSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(key1, key2) || !bytes.Equal(cc1, cc2) {
+		t.Fatal("expected deterministic derivation for identical seed and path")
+	}
+}
+
+func TestBaseDerivationPathFor(t *testing.T) {
+	tests := []struct {
+		coin string
+		want string
+	}{
+		{"evrice", "m/44'/1020'/0'/0/0"},
+		{"ethereum", "m/44'/60'/0'/0/0"},
+		{"bitcoin", "m/44'/0'/0'/0/0"},
+		{"testnet", "m/44'/1'/0'/0/0"},
+	}
+	for i, tt := range tests {
+		path, err := BaseDerivationPathFor(tt.coin)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if have := path.String(); have != tt.want {
+			t.Errorf("test %d: have %s, want %s", i, have, tt.want)
+		}
+	}
+	if _, err := BaseDerivationPathFor("dogecoin"); err == nil {
+		t.Error("expected an error for an unregistered coin type")
+	}
+}